@@ -1,12 +1,20 @@
 package main
 
 import (
+	"bufio"         // For line-oriented parsing of PO/flat-map files
+	"bytes"         // For in-memory CSV buffering and BOM stripping
 	"encoding/csv"  // For reading and writing CSV files
 	"encoding/json" // For parsing and generating JSON
+	"encoding/xml"  // For reading and writing XLIFF and Android strings.xml
+	"errors"        // For distinguishing a missing catalog file from a corrupt one
 	"flag"          // For command-line argument parsing
 	"fmt"           // For formatted output
+	"io"            // For format Read/Write against arbitrary streams
 	"os"            // For file operations
+	"path/filepath" // For inferring a format from a file extension, and walking -src
+	"regexp"        // For scanning Swift sources in -mode extract
 	"sort"          // For sorting languages and keys
+	"strconv"       // For quoting/escaping flat-map and PO values
 	"strings"       // For string manipulation
 )
 
@@ -26,8 +34,11 @@ type StringEntry struct {
 }
 
 // Localization represents a translation for a specific language within a StringEntry.
+// A localization either carries a single StringUnit, or a Variations set (plural
+// categories and/or device/width variants) - never both in a well-formed catalog.
 type Localization struct {
-	StringUnit StringUnit `json:"stringUnit"` // The actual translation data
+	StringUnit *StringUnit `json:"stringUnit,omitempty"` // The actual translation data
+	Variations *Variations `json:"variations,omitempty"` // CLDR plural / device variants, if any
 }
 
 // StringUnit holds the translation value and its state (e.g., "translated").
@@ -36,6 +47,36 @@ type StringUnit struct {
 	Value string `json:"value"` // The translated string
 }
 
+// Variations holds the alternate forms Xcode String Catalogs allow in place of a
+// single StringUnit: "plural", keyed by CLDR plural category (zero/one/two/few/many/other),
+// and "device", keyed by device/width identifier (e.g. "iphone", "mac").
+type Variations struct {
+	Plural map[string]VariationUnit `json:"plural,omitempty"`
+	Device map[string]VariationUnit `json:"device,omitempty"`
+}
+
+// VariationUnit wraps the StringUnit for a single plural category or device variant,
+// matching the nesting Xcode emits (`variations.plural.one.stringUnit`).
+type VariationUnit struct {
+	StringUnit StringUnit `json:"stringUnit"`
+}
+
+// pluralCategories lists the CLDR plural categories Xcode String Catalogs accept,
+// in their canonical ordering. These mirror the category names golang.org/x/text's
+// feature/plural package returns from Form.String() (Zero, One, Two, Few, Many, Other).
+var pluralCategories = []string{"zero", "one", "two", "few", "many", "other"}
+
+// isPluralCategory reports whether category is one of the CLDR plural categories
+// Xcode String Catalogs recognize.
+func isPluralCategory(category string) bool {
+	for _, c := range pluralCategories {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
+
 // readJsonFile reads and parses an .xcstrings JSON file into an Xcstrings struct.
 // Args:
 //
@@ -48,13 +89,18 @@ type StringUnit struct {
 func readJsonFile(filename string) (*Xcstrings, error) {
 	file, err := os.Open(filename)
 	if err != nil {
-		return nil, fmt.Errorf("error opening JSON file: %v", err)
+		return nil, fmt.Errorf("error opening JSON file: %w", err)
 	}
 	defer file.Close()
+	return decodeXcstrings(file)
+}
 
+// decodeXcstrings decodes an .xcstrings JSON document from r. It is the
+// stream-based core readJsonFile wraps, and what xcstringsFormat.Read uses
+// to satisfy the Format interface.
+func decodeXcstrings(r io.Reader) (*Xcstrings, error) {
 	var xc Xcstrings
-	decoder := json.NewDecoder(file)
-	if err := decoder.Decode(&xc); err != nil {
+	if err := json.NewDecoder(r).Decode(&xc); err != nil {
 		return nil, fmt.Errorf("error decoding JSON: %v", err)
 	}
 	return &xc, nil
@@ -98,6 +144,113 @@ func getSortedLanguages(xc *Xcstrings) []string {
 	return languages
 }
 
+// csvOptions controls the dialect readCsvFile/createCsvFile (and their
+// stream-based cores) read and write, so a CSV round-tripped through Excel
+// on Windows - UTF-8 BOM, ';' delimiter, or a non-UTF-8 encoding - doesn't
+// corrupt the first key or fail to parse.
+//
+// Encoding supports only "utf-8" and "windows-1252"/"cp1252", converted by
+// hand in decodeCsvBytes/encodeCsvBytes without golang.org/x/text; any other
+// encoding name (e.g. gbk, shift-jis) is rejected with an error.
+type csvOptions struct {
+	Delimiter rune   // Field delimiter; ',' if zero.
+	Encoding  string // "", "utf-8", or "windows-1252"/"cp1252"; "" means utf-8.
+	WriteBOM  bool   // Prepend a UTF-8 BOM on write, for Excel.
+}
+
+// csvDelimiter returns opts.Delimiter, defaulting to ','.
+func (opts csvOptions) csvDelimiter() rune {
+	if opts.Delimiter == 0 {
+		return ','
+	}
+	return opts.Delimiter
+}
+
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// cp1252HighBytes maps the Windows-1252 bytes 0x80-0x9F that diverge from
+// Latin-1 to their Unicode code points; bytes outside this range map to the
+// same code point in both encodings.
+var cp1252HighBytes = map[byte]rune{
+	0x80: '€', 0x82: '‚', 0x83: 'ƒ', 0x84: '„',
+	0x85: '…', 0x86: '†', 0x87: '‡', 0x88: 'ˆ',
+	0x89: '‰', 0x8A: 'Š', 0x8B: '‹', 0x8C: 'Œ',
+	0x8E: 'Ž', 0x91: '‘', 0x92: '’', 0x93: '“',
+	0x94: '”', 0x95: '•', 0x96: '–', 0x97: '—',
+	0x98: '˜', 0x99: '™', 0x9A: 'š', 0x9B: '›',
+	0x9C: 'œ', 0x9E: 'ž', 0x9F: 'Ÿ',
+}
+
+// decodeCsvBytes transforms raw into UTF-8 per encodingName, stripping a
+// leading UTF-8 BOM unconditionally (a BOM is never valid CSV content).
+// Only "utf-8" (the default) and "windows-1252"/"cp1252" are supported (see
+// csvOptions); anything else fails with a clear error
+// instead of silently mangling the first field.
+func decodeCsvBytes(raw []byte, encodingName string) ([]byte, error) {
+	raw = bytes.TrimPrefix(raw, utf8BOM)
+
+	switch normalizeEncodingName(encodingName) {
+	case "", "utf-8":
+		return raw, nil
+	case "windows-1252", "cp1252":
+		var b strings.Builder
+		b.Grow(len(raw))
+		for _, by := range raw {
+			if r, ok := cp1252HighBytes[by]; ok {
+				b.WriteRune(r)
+			} else {
+				b.WriteRune(rune(by))
+			}
+		}
+		return []byte(b.String()), nil
+	default:
+		return nil, fmt.Errorf("unsupported -encoding %q (only utf-8 and windows-1252 are built in)", encodingName)
+	}
+}
+
+// encodeCsvBytes is the inverse of decodeCsvBytes, transforming UTF-8 text
+// into encodingName for CSV output.
+func encodeCsvBytes(utf8Data []byte, encodingName string) ([]byte, error) {
+	switch normalizeEncodingName(encodingName) {
+	case "", "utf-8":
+		return utf8Data, nil
+	case "windows-1252", "cp1252":
+		var b []byte
+		for _, r := range string(utf8Data) {
+			if by, ok := cp1252ByteFor(r); ok {
+				b = append(b, by)
+				continue
+			}
+			return nil, fmt.Errorf("character %q has no windows-1252 representation", r)
+		}
+		return b, nil
+	default:
+		return nil, fmt.Errorf("unsupported -encoding %q (only utf-8 and windows-1252 are built in)", encodingName)
+	}
+}
+
+// cp1252ByteFor is the reverse lookup of cp1252HighBytes, falling back to a
+// direct Latin-1 byte for runes below 0x100 that aren't remapped.
+func cp1252ByteFor(r rune) (byte, bool) {
+	for by, mapped := range cp1252HighBytes {
+		if mapped == r {
+			return by, true
+		}
+	}
+	if r >= 0 && r < 0x100 {
+		if _, remapped := cp1252HighBytes[byte(r)]; remapped {
+			return 0, false
+		}
+		return byte(r), true
+	}
+	return 0, false
+}
+
+// normalizeEncodingName lowercases and trims encodingName for comparison.
+func normalizeEncodingName(encodingName string) string {
+	return strings.ToLower(strings.TrimSpace(encodingName))
+}
+
 // createCsvFile generates a CSV file from the parsed .xcstrings data.
 // The first column contains string keys, and subsequent columns contain translations for each language.
 // Args:
@@ -105,19 +258,26 @@ func getSortedLanguages(xc *Xcstrings) []string {
 //	xc: Pointer to the Xcstrings struct.
 //	languages: List of language codes to include as columns.
 //	outputFile: Path to the output CSV file.
+//	opts: CSV dialect (delimiter, encoding, BOM) to write with.
 //
 // Returns:
 //
 //	error: Any error encountered during file creation or writing.
-func createCsvFile(xc *Xcstrings, languages []string, outputFile string) error {
+func createCsvFile(xc *Xcstrings, languages []string, outputFile string, opts csvOptions) error {
 	file, err := os.Create(outputFile)
 	if err != nil {
 		return fmt.Errorf("error creating CSV file: %v", err)
 	}
 	defer file.Close()
+	return writeCsvToWriter(file, xc, languages, opts)
+}
 
-	writer := csv.NewWriter(file)
-	defer writer.Flush()
+// writeCsvToWriter is the stream-based core createCsvFile wraps, and what
+// csvFormat.Write uses to satisfy the Format interface.
+func writeCsvToWriter(w io.Writer, xc *Xcstrings, languages []string, opts csvOptions) error {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	writer.Comma = opts.csvDelimiter()
 
 	// Write header: empty first cell, then language codes
 	header := append([]string{""}, languages...)
@@ -134,26 +294,112 @@ func createCsvFile(xc *Xcstrings, languages []string, outputFile string) error {
 
 	// Write rows: key followed by translations
 	for _, key := range keys {
-		row := make([]string, len(languages)+1)
-		row[0] = key // First column is the key
 		entry := xc.Strings[key]
-		for i, lang := range languages {
-			if lang == xc.SourceLanguage && len(entry.Localizations) == 0 {
-				// Use key as the source language value if no localizations exist
-				row[i+1] = key
-			} else if loc, ok := entry.Localizations[lang]; ok {
-				// Use the translated value if available
-				row[i+1] = loc.StringUnit.Value
-			} else {
-				row[i+1] = "" // Empty if no translation
+
+		variationIDs := collectVariationIDs(entry)
+		if variationIDs == nil {
+			row := buildCsvRow(key, key, xc.SourceLanguage, languages, entry, "", "")
+			if err := writer.Write(row); err != nil {
+				return fmt.Errorf("error writing CSV row for key '%s': %v", key, err)
 			}
+			continue
 		}
-		if err := writer.Write(row); err != nil {
-			return fmt.Errorf("error writing CSV row for key '%s': %v", key, err)
+
+		// Variations expand into one synthetic row per plural category / device
+		// variant, e.g. "my.key|plural=one", so each CLDR category round-trips
+		// through its own CSV row per language.
+		for _, v := range variationIDs {
+			rowKey := fmt.Sprintf("%s|%s=%s", key, v.kind, v.id)
+			row := buildCsvRow(rowKey, key, xc.SourceLanguage, languages, entry, v.kind, v.id)
+			if err := writer.Write(row); err != nil {
+				return fmt.Errorf("error writing CSV row for key '%s': %v", rowKey, err)
+			}
 		}
 	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("error flushing CSV: %v", err)
+	}
 
-	return nil
+	encoded, err := encodeCsvBytes(buf.Bytes(), opts.Encoding)
+	if err != nil {
+		return err
+	}
+	if opts.WriteBOM {
+		if _, err := w.Write(utf8BOM); err != nil {
+			return fmt.Errorf("error writing CSV BOM: %v", err)
+		}
+	}
+	_, err = w.Write(encoded)
+	return err
+}
+
+// variationID identifies a single plural category or device variant belonging to an entry.
+type variationID struct {
+	kind string // "plural" or "device"
+	id   string // CLDR category (e.g. "one") or device identifier (e.g. "iphone")
+}
+
+// collectVariationIDs returns the union of plural categories and device variants
+// used across an entry's localizations, in canonical plural order followed by
+// device variants sorted alphabetically. It returns nil if entry has no variations.
+func collectVariationIDs(entry StringEntry) []variationID {
+	pluralSet := make(map[string]bool)
+	deviceSet := make(map[string]bool)
+	for _, loc := range entry.Localizations {
+		if loc.Variations == nil {
+			continue
+		}
+		for category := range loc.Variations.Plural {
+			pluralSet[category] = true
+		}
+		for device := range loc.Variations.Device {
+			deviceSet[device] = true
+		}
+	}
+	if len(pluralSet) == 0 && len(deviceSet) == 0 {
+		return nil
+	}
+
+	var ids []variationID
+	for _, category := range pluralCategories {
+		if pluralSet[category] {
+			ids = append(ids, variationID{kind: "plural", id: category})
+		}
+	}
+	devices := make([]string, 0, len(deviceSet))
+	for device := range deviceSet {
+		devices = append(devices, device)
+	}
+	sort.Strings(devices)
+	for _, device := range devices {
+		ids = append(ids, variationID{kind: "device", id: device})
+	}
+	return ids
+}
+
+// buildCsvRow produces one CSV row for either a plain entry (kind == "") or a
+// single plural/device variation of entry, filling in one cell per language.
+func buildCsvRow(rowKey, baseKey, sourceLanguage string, languages []string, entry StringEntry, kind, id string) []string {
+	row := make([]string, len(languages)+1)
+	row[0] = rowKey
+	for i, lang := range languages {
+		loc, ok := entry.Localizations[lang]
+		switch {
+		case kind == "" && !ok && lang == sourceLanguage:
+			// Use key as the source language value if no localizations exist
+			row[i+1] = baseKey
+		case kind == "" && ok && loc.StringUnit != nil:
+			row[i+1] = loc.StringUnit.Value
+		case kind == "plural" && ok && loc.Variations != nil:
+			row[i+1] = loc.Variations.Plural[id].StringUnit.Value
+		case kind == "device" && ok && loc.Variations != nil:
+			row[i+1] = loc.Variations.Device[id].StringUnit.Value
+		default:
+			row[i+1] = "" // Empty if no translation
+		}
+	}
+	return row
 }
 
 // readCsvFile reads a CSV file and reconstructs an Xcstrings struct.
@@ -167,14 +413,31 @@ func createCsvFile(xc *Xcstrings, languages []string, outputFile string) error {
 //	*Xcstrings: Pointer to the reconstructed Xcstrings struct.
 //	[]string: List of language codes from the header.
 //	error: Any error encountered during file reading or parsing.
-func readCsvFile(filename string) (*Xcstrings, []string, error) {
+func readCsvFile(filename string, opts csvOptions) (*Xcstrings, []string, error) {
 	file, err := os.Open(filename)
 	if err != nil {
 		return nil, nil, fmt.Errorf("error opening CSV file: %v", err)
 	}
 	defer file.Close()
+	return readCsvFromReader(file, opts)
+}
+
+// readCsvFromReader is the stream-based core readCsvFile wraps, and what
+// csvFormat.Read uses to satisfy the Format interface. It strips a leading
+// UTF-8 BOM and applies opts.Encoding before handing bytes to csv.Reader, so
+// a CSV saved by Excel on Windows doesn't corrupt the first key or fail to parse.
+func readCsvFromReader(r io.Reader, opts csvOptions) (*Xcstrings, []string, error) {
+	raw, err := io.ReadAll(r)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error reading CSV: %v", err)
+	}
+	decoded, err := decodeCsvBytes(raw, opts.Encoding)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	reader := csv.NewReader(file)
+	reader := csv.NewReader(bytes.NewReader(decoded))
+	reader.Comma = opts.csvDelimiter()
 	records, err := reader.ReadAll()
 	if err != nil {
 		return nil, nil, fmt.Errorf("error reading CSV: %v", err)
@@ -201,35 +464,84 @@ func readCsvFile(filename string) (*Xcstrings, []string, error) {
 		if len(row) == 0 {
 			continue
 		}
-		key := row[0] // Key from first column
-		if keySet[key] {
-			return nil, nil, fmt.Errorf("duplicate key found: %s", key)
+		rowKey := row[0] // Key from first column
+		if keySet[rowKey] {
+			return nil, nil, fmt.Errorf("duplicate key found: %s", rowKey)
+		}
+		keySet[rowKey] = true
+
+		baseKey, kind, id, isVariant, err := parseVariationKey(rowKey)
+		if err != nil {
+			return nil, nil, err
 		}
-		keySet[key] = true
 
-		entry := StringEntry{
-			ExtractionState: "manual", // Default value
-			Localizations:   make(map[string]Localization),
+		entry, exists := xc.Strings[baseKey]
+		if !exists {
+			entry = StringEntry{
+				ExtractionState: "manual", // Default value
+				Localizations:   make(map[string]Localization),
+			}
 		}
+
 		for i, translation := range row[1:] {
 			if i >= len(languages) {
 				break
 			}
-			if strings.TrimSpace(translation) != "" {
-				entry.Localizations[languages[i]] = Localization{
-					StringUnit: StringUnit{
-						State: "translated",
-						Value: translation,
-					},
+			if strings.TrimSpace(translation) == "" {
+				continue
+			}
+			lang := languages[i]
+			loc := entry.Localizations[lang]
+			unit := StringUnit{State: "translated", Value: translation}
+			if !isVariant {
+				loc.StringUnit = &unit
+			} else {
+				if loc.Variations == nil {
+					loc.Variations = &Variations{}
+				}
+				if kind == "plural" {
+					if loc.Variations.Plural == nil {
+						loc.Variations.Plural = make(map[string]VariationUnit)
+					}
+					loc.Variations.Plural[id] = VariationUnit{StringUnit: unit}
+				} else {
+					if loc.Variations.Device == nil {
+						loc.Variations.Device = make(map[string]VariationUnit)
+					}
+					loc.Variations.Device[id] = VariationUnit{StringUnit: unit}
 				}
 			}
+			entry.Localizations[lang] = loc
 		}
-		xc.Strings[key] = entry
+		xc.Strings[baseKey] = entry
 	}
 
 	return xc, languages, nil
 }
 
+// parseVariationKey splits a CSV row key into its base key and, if present, the
+// plural category or device identifier encoded as a "|plural=<category>" or
+// "|device=<id>" suffix (see createCsvFile). Rows without such a suffix return
+// isVariant == false and baseKey == rowKey unchanged.
+func parseVariationKey(rowKey string) (baseKey, kind, id string, isVariant bool, err error) {
+	idx := strings.LastIndex(rowKey, "|")
+	if idx == -1 {
+		return rowKey, "", "", false, nil
+	}
+
+	suffix := rowKey[idx+1:]
+	parts := strings.SplitN(suffix, "=", 2)
+	if len(parts) != 2 || (parts[0] != "plural" && parts[0] != "device") {
+		return rowKey, "", "", false, nil
+	}
+
+	kind, id = parts[0], parts[1]
+	if kind == "plural" && !isPluralCategory(id) {
+		return "", "", "", false, fmt.Errorf("unknown plural category %q for key %q: must be one of %s", id, rowKey, strings.Join(pluralCategories, ", "))
+	}
+	return rowKey[:idx], kind, id, true, nil
+}
+
 // writeJsonFile converts an Xcstrings struct to a formatted JSON file and prints it for verification.
 // Args:
 //
@@ -263,7 +575,7 @@ func writeJsonFile(xc *Xcstrings, outputFile string) error {
 // Returns:
 //
 //	error: Any error encountered during the conversion.
-func jsonToCsv(inputFile, outputFile string) error {
+func jsonToCsv(inputFile, outputFile string, opts csvOptions) error {
 	xc, err := readJsonFile(inputFile)
 	if err != nil {
 		return err
@@ -274,7 +586,7 @@ func jsonToCsv(inputFile, outputFile string) error {
 		return fmt.Errorf("no languages found in the JSON file")
 	}
 
-	if err := createCsvFile(xc, languages, outputFile); err != nil {
+	if err := createCsvFile(xc, languages, outputFile, opts); err != nil {
 		return err
 	}
 
@@ -291,8 +603,8 @@ func jsonToCsv(inputFile, outputFile string) error {
 // Returns:
 //
 //	error: Any error encountered during the conversion.
-func csvToJson(inputFile, outputFile string) error {
-	xc, languages, err := readCsvFile(inputFile)
+func csvToJson(inputFile, outputFile string, opts csvOptions) error {
+	xc, languages, err := readCsvFile(inputFile, opts)
 	if err != nil {
 		return err
 	}
@@ -306,43 +618,1561 @@ func csvToJson(inputFile, outputFile string) error {
 	return nil
 }
 
-// main is the entry point of the program, parsing command-line flags and executing the chosen mode.
-func main() {
-	// Define command-line flags
-	mode := flag.String("mode", "", "Operation mode: 'json2csv' or 'csv2json'")
-	input := flag.String("input", "", "Input file path")
-	output := flag.String("output", "", "Output file path")
-	flag.Parse()
+// mergeSummary counts how a merge pass (see mergeTranslations) affected the
+// base catalog's keys, reported to stderr so translators can see the diff.
+type mergeSummary struct {
+	Added     int
+	Updated   int
+	Unchanged int
+	Stale     int
+	Invalid   int // rows whose key couldn't be parsed (e.g. an unknown plural-category suffix); reported, not silently dropped
+}
 
-	// Validate mode
-	if *mode != "json2csv" && *mode != "csv2json" {
-		fmt.Println("Error: -mode must be 'json2csv' or 'csv2json'")
-		fmt.Println("Usage:")
-		fmt.Println("  go run localization_converter.go -mode json2csv -input Localizable.xcstrings -output translations.csv")
-		fmt.Println("  go run localization_converter.go -mode csv2json -input translations.csv -output Localizable.xcstrings")
-		os.Exit(1)
+// readCsvRows reads filename's header languages and remaining raw rows,
+// without reconstructing an Xcstrings struct - mergeTranslations needs the
+// base catalog's existing data untouched, not a fresh one built from CSV.
+func readCsvRows(filename string, opts csvOptions) ([]string, [][]string, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error opening CSV file: %v", err)
 	}
+	defer file.Close()
 
-	// Validate input/output flags
-	if *input == "" || *output == "" {
-		fmt.Println("Error: -input and -output flags are required")
-		fmt.Println("Usage:")
-		fmt.Println("  go run localization_converter.go -mode json2csv -input Localizable.xcstrings -output translations.csv")
-		fmt.Println("  go run localization_converter.go -mode csv2json -input translations.csv -output Localizable.xcstrings")
-		os.Exit(1)
+	raw, err := io.ReadAll(file)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error reading CSV: %v", err)
+	}
+	decoded, err := decodeCsvBytes(raw, opts.Encoding)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	// Execute the chosen mode
-	switch *mode {
-	case "json2csv":
-		if err := jsonToCsv(*input, *output); err != nil {
-			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-			os.Exit(1)
+	reader := csv.NewReader(bytes.NewReader(decoded))
+	reader.Comma = opts.csvDelimiter()
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, nil, fmt.Errorf("error reading CSV: %v", err)
+	}
+	if len(records) < 2 {
+		return nil, nil, fmt.Errorf("CSV file must have at least 2 rows")
+	}
+
+	languages := records[0][1:]
+	if len(languages) == 0 {
+		return nil, nil, fmt.Errorf("no languages found in the CSV file")
+	}
+	return languages, records[1:], nil
+}
+
+// mergeTranslations reconciles translated CSV rows into base in place, the
+// way go-i18n's merge_command folds a translated message file back into the
+// source catalog without discarding what's already there. For each cell:
+// an empty CSV value leaves the existing translation untouched (never
+// deletes it); a value equal to the source-language text is flagged
+// "needs_review" rather than trusted as a real translation; anything else
+// is recorded as "translated". The source language column itself is never
+// written back - only existing, pre-extracted localizations are preserved.
+// A row whose key has an unparseable variation suffix (e.g. an unknown
+// plural category) is skipped and counted/reported as Invalid, rather than
+// dropped without a trace.
+func mergeTranslations(base *Xcstrings, languages []string, rows [][]string) mergeSummary {
+	var summary mergeSummary
+	touched := make(map[string]bool)
+	added := make(map[string]bool)
+	changed := make(map[string]bool)
+
+	for _, row := range rows {
+		if len(row) == 0 {
+			continue
 		}
-	case "csv2json":
-		if err := csvToJson(*input, *output); err != nil {
-			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-			os.Exit(1)
+		rowKey := row[0]
+		baseKey, kind, id, _, err := parseVariationKey(rowKey)
+		if err != nil {
+			summary.Invalid++
+			fmt.Fprintf(os.Stderr, "warning: skipping CSV row %q: %v\n", rowKey, err)
+			// If the row's (malformed) base key already exists in the catalog,
+			// mark it touched so it isn't also miscounted as Stale; a base key
+			// that doesn't exist yet is left uncreated and uncounted, since this
+			// row never produced one (only Invalid reflects it).
+			candidateBaseKey := rowKey
+			if idx := strings.LastIndex(rowKey, "|"); idx != -1 {
+				candidateBaseKey = rowKey[:idx]
+			}
+			if _, exists := base.Strings[candidateBaseKey]; exists {
+				touched[candidateBaseKey] = true
+			}
+			continue
+		}
+		touched[baseKey] = true
+
+		entry, exists := base.Strings[baseKey]
+		if !exists {
+			entry = StringEntry{ExtractionState: "manual"}
+			added[baseKey] = true
+		}
+		if entry.Localizations == nil {
+			entry.Localizations = make(map[string]Localization)
+		}
+		base.Strings[baseKey] = entry
+
+		sourceValue := variantValue(entry.Localizations[base.SourceLanguage], kind, id)
+
+		for i, translation := range row[1:] {
+			if i >= len(languages) {
+				break
+			}
+			lang := languages[i]
+			if lang == base.SourceLanguage {
+				continue // the source text comes from extraction, not a translation CSV
+			}
+			trimmed := strings.TrimSpace(translation)
+			if trimmed == "" {
+				continue // blank cell: keep whatever translation already exists
+			}
+
+			loc := entry.Localizations[lang]
+			previous := variantValue(loc, kind, id)
+
+			state := "translated"
+			if sourceValue != "" && trimmed == sourceValue {
+				state = "needs_review"
+			}
+			setVariantValue(&loc, kind, id, StringUnit{State: state, Value: trimmed})
+			entry.Localizations[lang] = loc
+
+			if previous != trimmed {
+				changed[baseKey] = true
+			}
+		}
+	}
+
+	for key := range touched {
+		switch {
+		case added[key]:
+			summary.Added++
+		case changed[key]:
+			summary.Updated++
+		default:
+			summary.Unchanged++
+		}
+	}
+	for key := range base.Strings {
+		if !touched[key] {
+			summary.Stale++
+		}
+	}
+	return summary
+}
+
+// variantValue reads the current string for a plain localization (kind == "")
+// or for a single plural/device variant (kind == "plural"/"device", id the
+// category/device key), returning "" if it isn't set.
+func variantValue(loc Localization, kind, id string) string {
+	switch kind {
+	case "":
+		if loc.StringUnit != nil {
+			return loc.StringUnit.Value
+		}
+	case "plural":
+		if loc.Variations != nil {
+			return loc.Variations.Plural[id].StringUnit.Value
+		}
+	case "device":
+		if loc.Variations != nil {
+			return loc.Variations.Device[id].StringUnit.Value
+		}
+	}
+	return ""
+}
+
+// setVariantValue writes unit into loc as a plain StringUnit (kind == "") or
+// into the given plural/device variant, creating the Variations maps as needed.
+func setVariantValue(loc *Localization, kind, id string, unit StringUnit) {
+	switch kind {
+	case "":
+		loc.StringUnit = &unit
+	case "plural":
+		if loc.Variations == nil {
+			loc.Variations = &Variations{}
+		}
+		if loc.Variations.Plural == nil {
+			loc.Variations.Plural = make(map[string]VariationUnit)
+		}
+		loc.Variations.Plural[id] = VariationUnit{StringUnit: unit}
+	case "device":
+		if loc.Variations == nil {
+			loc.Variations = &Variations{}
+		}
+		if loc.Variations.Device == nil {
+			loc.Variations.Device = make(map[string]VariationUnit)
+		}
+		loc.Variations.Device[id] = VariationUnit{StringUnit: unit}
+	}
+}
+
+// mergeCsvIntoXcstrings loads baseFile, folds csvFile's translations into it
+// via mergeTranslations, and writes the result to outputFile (typically the
+// same path as baseFile, for repeated translation passes).
+// Args:
+//
+//	baseFile: Path to the existing .xcstrings catalog to preserve metadata from.
+//	csvFile: Path to the translated CSV to reconcile in.
+//	outputFile: Path to write the merged .xcstrings catalog to.
+//
+// Returns:
+//
+//	error: Any error encountered during loading, merging, or writing.
+func mergeCsvIntoXcstrings(baseFile, csvFile, outputFile string, opts csvOptions) error {
+	base, err := readJsonFile(baseFile)
+	if err != nil {
+		return err
+	}
+	languages, rows, err := readCsvRows(csvFile, opts)
+	if err != nil {
+		return err
+	}
+
+	summary := mergeTranslations(base, languages, rows)
+	fmt.Fprintf(os.Stderr, "merge summary: %d added, %d updated, %d unchanged, %d stale, %d invalid\n",
+		summary.Added, summary.Updated, summary.Unchanged, summary.Stale, summary.Invalid)
+
+	if err := writeJsonFile(base, outputFile); err != nil {
+		return err
+	}
+	fmt.Printf("JSON file '%s' updated successfully.\n", outputFile)
+	return nil
+}
+
+// swiftLocalizationCallRegexps matches the Swift call sites this tool can
+// extract localization keys from: the classic NSLocalizedString(_:comment:)
+// macro, SwiftUI/Foundation's String(localized:), and LocalizedStringKey(_:).
+// Each must have exactly one capture group for the key literal.
+var swiftLocalizationCallRegexps = []*regexp.Regexp{
+	regexp.MustCompile(`NSLocalizedString\(\s*"((?:[^"\\]|\\.)*)"`),
+	regexp.MustCompile(`String\(\s*localized:\s*"((?:[^"\\]|\\.)*)"`),
+	regexp.MustCompile(`LocalizedStringKey\(\s*"((?:[^"\\]|\\.)*)"`),
+}
+
+// swiftCommentRegexp captures NSLocalizedString's optional comment: argument,
+// applied to the same line/call the key regex matched against.
+var swiftCommentRegexp = regexp.MustCompile(`comment:\s*"((?:[^"\\]|\\.)*)"`)
+
+// extractedString is one localization call site found in Swift source.
+type extractedString struct {
+	Key     string
+	Comment string
+}
+
+// extractSwiftStrings walks srcDir for .swift files and collects every
+// NSLocalizedString/String(localized:)/LocalizedStringKey call site, in the
+// style of golang.org/x/text/message/pipeline's source extractor. It's a
+// regex/AST-lite scan, not a full Swift parser, run one source line at a
+// time: a call whose key literal isn't on the same line as the call name is
+// not matched, and a comment: argument on a different line from the key is
+// not picked up.
+func extractSwiftStrings(srcDir string) (map[string]extractedString, error) {
+	found := make(map[string]extractedString)
+
+	err := filepath.Walk(srcDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || !strings.HasSuffix(path, ".swift") {
+			return nil
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("error reading %s: %v", path, err)
+		}
+
+		for _, line := range strings.Split(string(data), "\n") {
+			for _, re := range swiftLocalizationCallRegexps {
+				match := re.FindStringSubmatch(line)
+				if match == nil {
+					continue
+				}
+				key, err := unescapeSwiftStringLiteral(match[1])
+				if err != nil {
+					return fmt.Errorf("error parsing localization key in %s: %v", path, err)
+				}
+				entry := extractedString{Key: key}
+				if commentMatch := swiftCommentRegexp.FindStringSubmatch(line); commentMatch != nil {
+					comment, err := unescapeSwiftStringLiteral(commentMatch[1])
+					if err != nil {
+						return fmt.Errorf("error parsing comment in %s: %v", path, err)
+					}
+					entry.Comment = comment
+				}
+				found[key] = entry
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return found, nil
+}
+
+// unescapeSwiftStringLiteral decodes the backslash escapes Swift string
+// literals support that also appear in source (\", \\, \n, \t); anything
+// else is passed through unchanged.
+func unescapeSwiftStringLiteral(s string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i == len(s)-1 {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case '"':
+			b.WriteByte('"')
+		case '\\':
+			b.WriteByte('\\')
+		case 'n':
+			b.WriteByte('\n')
+		case 't':
+			b.WriteByte('\t')
+		default:
+			b.WriteByte('\\')
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String(), nil
+}
+
+// extractSummary counts how mergeExtractedStrings changed the catalog.
+type extractSummary struct {
+	Added       int
+	Resurrected int
+	Stale       int
+}
+
+// mergeExtractedStrings folds freshly-extracted Swift call sites into base in
+// place, matching Xcode's own extraction semantics: a key new to the source
+// gets extractionState "extracted_with_value" and a source-language
+// stringUnit seeded from the key itself; a key that reappears after having
+// gone stale is restored to "extracted_with_value"; a previously-extracted
+// key no longer found in source becomes "stale" rather than being deleted,
+// so its existing translations survive until the string is used again.
+// Manually-added entries (extractionState "manual") are left alone either way.
+func mergeExtractedStrings(base *Xcstrings, found map[string]extractedString) extractSummary {
+	var summary extractSummary
+
+	for key := range found {
+		entry, exists := base.Strings[key]
+		switch {
+		case !exists:
+			entry = StringEntry{
+				ExtractionState: "extracted_with_value",
+				Localizations: map[string]Localization{
+					base.SourceLanguage: {StringUnit: &StringUnit{State: "translated", Value: key}},
+				},
+			}
+			base.Strings[key] = entry
+			summary.Added++
+		case entry.ExtractionState == "stale":
+			entry.ExtractionState = "extracted_with_value"
+			base.Strings[key] = entry
+			summary.Resurrected++
+		}
+	}
+
+	for key, entry := range base.Strings {
+		if _, stillPresent := found[key]; stillPresent {
+			continue
+		}
+		if entry.ExtractionState == "manual" {
+			continue // not ours to manage - Xcode never marks manual entries stale
+		}
+		if entry.ExtractionState != "stale" {
+			entry.ExtractionState = "stale"
+			base.Strings[key] = entry
+			summary.Stale++
+		}
+	}
+	return summary
+}
+
+// extractSwiftIntoXcstrings scans srcDir for localization call sites and
+// merges them into outputFile's existing catalog (or a fresh one, if
+// outputFile doesn't exist yet), preserving existing translations. A catalog
+// that exists but fails to read (bad permissions, corrupt JSON, etc.) is a
+// hard error - it must never be silently replaced with an empty one.
+// Args:
+//
+//	srcDir: Root directory to walk for .swift files.
+//	outputFile: Path to the .xcstrings catalog to merge extracted keys into.
+//	sourceLanguage: Source language to seed a new catalog with; ignored if outputFile already exists.
+//
+// Returns:
+//
+//	error: Any error encountered during scanning, merging, or writing.
+func extractSwiftIntoXcstrings(srcDir, outputFile, sourceLanguage string) error {
+	base, err := readJsonFile(outputFile)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("error reading existing catalog %q: %w", outputFile, err)
 		}
+		if sourceLanguage == "" {
+			sourceLanguage = "en"
+		}
+		base = &Xcstrings{SourceLanguage: sourceLanguage, Strings: make(map[string]StringEntry), Version: "1.0"}
+	}
+
+	found, err := extractSwiftStrings(srcDir)
+	if err != nil {
+		return err
+	}
+
+	summary := mergeExtractedStrings(base, found)
+	fmt.Fprintf(os.Stderr, "extract summary: %d added, %d resurrected, %d stale\n",
+		summary.Added, summary.Resurrected, summary.Stale)
+
+	if err := writeJsonFile(base, outputFile); err != nil {
+		return err
+	}
+	fmt.Printf("JSON file '%s' updated successfully.\n", outputFile)
+	return nil
+}
+
+// appleStringsEscape escapes s for use inside a double-quoted .strings or
+// .stringsdict string literal, matching the grammar Apple's own tools emit:
+// backslash and double-quote are backslash-escaped, newlines become \n, and
+// any other non-ASCII rune is left as-is (modern Xcode writes UTF-8 .strings
+// files directly rather than \Uxxxx-escaping them, and genstrings/plutil
+// round-trip that form fine).
+func appleStringsEscape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '\\':
+			b.WriteString(`\\`)
+		case '"':
+			b.WriteString(`\"`)
+		case '\n':
+			b.WriteString(`\n`)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// appleStringsQuote wraps s in double quotes, escaping its contents per
+// appleStringsEscape.
+func appleStringsQuote(s string) string {
+	return `"` + appleStringsEscape(s) + `"`
+}
+
+// compileStringUnits renders the plain (non-variant) key/value pairs for lang
+// into the body of a legacy .strings file, skipping entries that have no
+// translation, are in the "new" state, or have an empty value - Xcode never
+// compiles those into a build product either. Entries skipped for a "new"
+// state or an empty value are reported to stderr; keys with no plain
+// translation for lang are skipped without a warning.
+func compileStringUnits(xc *Xcstrings, keys []string, lang string) string {
+	var b strings.Builder
+	for _, key := range keys {
+		entry := xc.Strings[key]
+		loc, ok := entry.Localizations[lang]
+		if !ok || loc.Variations != nil {
+			continue // no plain translation for this language, or it's plural/device-variant (handled separately)
+		}
+		if loc.StringUnit == nil {
+			continue
+		}
+		if loc.StringUnit.State == "new" || loc.StringUnit.Value == "" {
+			fmt.Fprintf(os.Stderr, "warning: skipping %q for %q: %s\n", key, lang, skipReason(loc.StringUnit))
+			continue
+		}
+		fmt.Fprintf(&b, "%s = %s;\n", appleStringsQuote(key), appleStringsQuote(loc.StringUnit.Value))
+	}
+	return b.String()
+}
+
+// skipReason describes why a .strings entry was omitted from compile output.
+func skipReason(u *StringUnit) string {
+	if u.Value == "" {
+		return "empty value"
+	}
+	return fmt.Sprintf("state is %q", u.State)
+}
+
+// plistEscapeText escapes s for use as character data inside a plist/XML
+// element (the .stringsdict body), using encoding/xml's own entity escaping
+// so "&", "<", ">", and quotes are never emitted raw. Unlike
+// appleStringsEscape - which only covers the backslash-quoting grammar of
+// double-quoted .strings literals - this is what's required for content
+// embedded in an actual XML document.
+func plistEscapeText(s string) string {
+	var b strings.Builder
+	if err := xml.EscapeText(&b, []byte(s)); err != nil {
+		// xml.EscapeText only fails if the Writer does; strings.Builder never does.
+		panic(err)
+	}
+	return b.String()
+}
+
+// compileStringsdict renders the plural-variant entries for lang as a
+// .stringsdict plist. Each key becomes a top-level dict whose
+// NSStringLocalizedFormatKey references a synthetic "value" substitution
+// filled in by the plural categories present. Device variants aren't
+// representable in a .stringsdict (Xcode handles those at the .strings/build
+// level instead), so keys that only have device variations are skipped here
+// with a warning. Returns "" if lang has no pluralized keys at all, so the
+// caller can skip writing the file.
+func compileStringsdict(xc *Xcstrings, keys []string, lang string) string {
+	var entries strings.Builder
+	for _, key := range keys {
+		entry := xc.Strings[key]
+		loc, ok := entry.Localizations[lang]
+		if !ok || loc.Variations == nil {
+			continue
+		}
+		if len(loc.Variations.Plural) == 0 {
+			if len(loc.Variations.Device) > 0 {
+				fmt.Fprintf(os.Stderr, "warning: skipping %q for %q: device variants are not supported by -mode compile\n", key, lang)
+			}
+			continue
+		}
+
+		var cases strings.Builder
+		any := false
+		for _, category := range pluralCategories {
+			unit, ok := loc.Variations.Plural[category]
+			if !ok {
+				continue
+			}
+			if unit.StringUnit.State == "new" || unit.StringUnit.Value == "" {
+				fmt.Fprintf(os.Stderr, "warning: skipping %q plural=%s for %q: %s\n", key, category, lang, skipReason(&unit.StringUnit))
+				continue
+			}
+			any = true
+			fmt.Fprintf(&cases, "\t\t\t<key>%s</key>\n\t\t\t<string>%s</string>\n", category, plistEscapeText(unit.StringUnit.Value))
+		}
+		if !any {
+			continue
+		}
+
+		fmt.Fprintf(&entries, "\t<key>%s</key>\n\t<dict>\n", plistEscapeText(key))
+		entries.WriteString("\t\t<key>NSStringLocalizedFormatKey</key>\n\t\t<string>%#@value@</string>\n")
+		entries.WriteString("\t\t<key>value</key>\n\t\t<dict>\n")
+		entries.WriteString("\t\t\t<key>NSStringFormatSpecTypeKey</key>\n\t\t\t<string>NSStringPluralRuleType</string>\n")
+		entries.WriteString("\t\t\t<key>NSStringFormatValueTypeKey</key>\n\t\t\t<string>d</string>\n")
+		entries.WriteString(cases.String())
+		entries.WriteString("\t\t</dict>\n\t</dict>\n")
+	}
+	if entries.Len() == 0 {
+		return ""
+	}
+	var doc strings.Builder
+	doc.WriteString("<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n")
+	doc.WriteString("<!DOCTYPE plist PUBLIC \"-//Apple//DTD PLIST 1.0//EN\" \"http://www.apple.com/DTDs/PropertyList-1.0.dtd\">\n")
+	doc.WriteString("<plist version=\"1.0\">\n<dict>\n")
+	doc.WriteString(entries.String())
+	doc.WriteString("</dict>\n</plist>\n")
+	return doc.String()
+}
+
+// compileXcstrings reads inputFile and writes one legacy Localizable.strings
+// file per language (and a Localizable.stringsdict alongside it, for
+// languages that have pluralized keys) under outDir/<lang>.lproj/, mirroring
+// what Xcode's build-time compiler produces from a String Catalog.
+// Args:
+//
+//	inputFile: Path to the .xcstrings catalog to compile.
+//	outDir: Root directory under which <lang>.lproj directories are created.
+//
+// Returns:
+//
+//	error: Any error encountered reading the catalog or writing output files.
+func compileXcstrings(inputFile, outDir string) error {
+	xc, err := readJsonFile(inputFile)
+	if err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(xc.Strings))
+	for key := range xc.Strings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	languages := getSortedLanguages(xc)
+	for _, lang := range languages {
+		dir := filepath.Join(outDir, lang+".lproj")
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("error creating %s: %v", dir, err)
+		}
+
+		stringsPath := filepath.Join(dir, "Localizable.strings")
+		if err := os.WriteFile(stringsPath, []byte(compileStringUnits(xc, keys, lang)), 0644); err != nil {
+			return fmt.Errorf("error writing %s: %v", stringsPath, err)
+		}
+
+		if dict := compileStringsdict(xc, keys, lang); dict != "" {
+			stringsdictPath := filepath.Join(dir, "Localizable.stringsdict")
+			if err := os.WriteFile(stringsdictPath, []byte(dict), 0644); err != nil {
+				return fmt.Errorf("error writing %s: %v", stringsdictPath, err)
+			}
+		}
+	}
+
+	fmt.Printf("Compiled '%s' to '%s' for %d language(s).\n", inputFile, outDir, len(languages))
+	return nil
+}
+
+// Format is implemented by every localization file format the converter
+// understands. Read parses r into an in-memory Xcstrings catalog; Write
+// serializes a catalog back out in that format. xcstrings and CSV are
+// inherently multi-language; the rest (xliff, android, po, json, yaml, toml)
+// represent a single locale per file (see requireLang). xliff and po also
+// carry the source-language text next to that locale, so their Read
+// populates both the source language and the -lang locale.
+type Format interface {
+	Read(r io.Reader) (*Xcstrings, error)
+	Write(w io.Writer, xc *Xcstrings) error
+}
+
+// formatFactory builds a Format for a given -lang value. Multi-language
+// formats (xcstrings, csv) ignore lang entirely.
+// formatFactory builds a Format for a given -lang value and CSV dialect; only
+// the "csv" format consults csvOpts.
+type formatFactory func(lang string, csvOpts csvOptions) Format
+
+// formatRegistry maps the names accepted by -from/-to to their factories.
+var formatRegistry = map[string]formatFactory{
+	"xcstrings": func(lang string, csvOpts csvOptions) Format { return xcstringsFormat{} },
+	"csv":       func(lang string, csvOpts csvOptions) Format { return csvFormat{opts: csvOpts} },
+	"xliff":     func(lang string, csvOpts csvOptions) Format { return xliffFormat{lang: lang} },
+	"android":   func(lang string, csvOpts csvOptions) Format { return androidFormat{lang: lang} },
+	"po":        func(lang string, csvOpts csvOptions) Format { return poFormat{lang: lang} },
+	"json":      func(lang string, csvOpts csvOptions) Format { return flatMapFormat{lang: lang, kind: flatJSON} },
+	"yaml":      func(lang string, csvOpts csvOptions) Format { return flatMapFormat{lang: lang, kind: flatYAML} },
+	"toml":      func(lang string, csvOpts csvOptions) Format { return flatMapFormat{lang: lang, kind: flatTOML} },
+}
+
+// lookupFormat resolves name to a Format via formatRegistry, passing lang and
+// csvOpts through to formats that need them (see requireLang, csvOptions).
+func lookupFormat(name, lang string, csvOpts csvOptions) (Format, error) {
+	factory, ok := formatRegistry[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown format %q (known formats: %s)", name, strings.Join(knownFormatNames(), ", "))
+	}
+	return factory(lang, csvOpts), nil
+}
+
+// knownFormatNames returns the registered format names, sorted for error messages.
+func knownFormatNames() []string {
+	names := make([]string, 0, len(formatRegistry))
+	for name := range formatRegistry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// formatFromExt infers a registry format name from a file's extension, so
+// -from/-to can be omitted when the file names are unambiguous.
+func formatFromExt(filename string) (string, bool) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".xcstrings":
+		return "xcstrings", true
+	case ".csv":
+		return "csv", true
+	case ".xliff", ".xlf":
+		return "xliff", true
+	case ".xml":
+		return "android", true
+	case ".po", ".pot":
+		return "po", true
+	case ".json":
+		return "json", true
+	case ".yaml", ".yml":
+		return "yaml", true
+	case ".toml":
+		return "toml", true
+	default:
+		return "", false
+	}
+}
+
+// requireLang returns lang, or an error if it's empty, for formats that can
+// only ever represent a single locale per file.
+func requireLang(lang, formatName string) (string, error) {
+	if lang == "" {
+		return "", fmt.Errorf("-lang is required when converting with the %q format (it stores a single locale per file)", formatName)
+	}
+	return lang, nil
+}
+
+// xcstringsFormat reads and writes the native .xcstrings JSON structure.
+type xcstringsFormat struct{}
+
+func (xcstringsFormat) Read(r io.Reader) (*Xcstrings, error) {
+	return decodeXcstrings(r)
+}
+
+func (xcstringsFormat) Write(w io.Writer, xc *Xcstrings) error {
+	jsonData, err := json.MarshalIndent(xc, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshalling JSON: %v", err)
+	}
+	_, err = w.Write(jsonData)
+	return err
+}
+
+// csvFormat reads and writes this tool's own multi-language CSV layout.
+// csvFormat reads and writes this tool's own multi-language CSV layout, in
+// the dialect described by opts (delimiter, encoding, BOM).
+type csvFormat struct {
+	opts csvOptions
+}
+
+func (f csvFormat) Read(r io.Reader) (*Xcstrings, error) {
+	xc, _, err := readCsvFromReader(r, f.opts)
+	return xc, err
+}
+
+func (f csvFormat) Write(w io.Writer, xc *Xcstrings) error {
+	return writeCsvToWriter(w, xc, getSortedLanguages(xc), f.opts)
+}
+
+// --- XLIFF 1.2 -------------------------------------------------------------
+
+type xliffDocument struct {
+	XMLName xml.Name  `xml:"urn:oasis:names:tc:xliff:document:1.2 xliff"`
+	Version string    `xml:"version,attr"`
+	File    xliffFile `xml:"file"`
+}
+
+type xliffFile struct {
+	Original       string    `xml:"original,attr"`
+	SourceLanguage string    `xml:"source-language,attr"`
+	TargetLanguage string    `xml:"target-language,attr,omitempty"`
+	Datatype       string    `xml:"datatype,attr"`
+	Body           xliffBody `xml:"body"`
+}
+
+type xliffBody struct {
+	TransUnits []xliffTransUnit `xml:"trans-unit"`
+}
+
+type xliffTransUnit struct {
+	ID     string `xml:"id,attr"`
+	Source string `xml:"source"`
+	Target string `xml:"target,omitempty"`
+}
+
+// xliffFormat reads and writes XLIFF 1.2, the de-facto translation-exchange
+// format most CAT tools import/export. A file carries one target locale
+// (lang); variations (see Variations) have no XLIFF 1.2 equivalent and are
+// dropped with a warning.
+type xliffFormat struct {
+	lang string
+}
+
+func (f xliffFormat) Read(r io.Reader) (*Xcstrings, error) {
+	var doc xliffDocument
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("error decoding XLIFF: %v", err)
+	}
+
+	sourceLang := doc.File.SourceLanguage
+	if sourceLang == "" {
+		sourceLang = "en"
+	}
+	targetLang := doc.File.TargetLanguage
+	if targetLang == "" {
+		targetLang = f.lang
+	}
+
+	xc := &Xcstrings{SourceLanguage: sourceLang, Strings: make(map[string]StringEntry), Version: "1.0"}
+	for _, tu := range doc.File.Body.TransUnits {
+		entry := StringEntry{ExtractionState: "manual", Localizations: make(map[string]Localization)}
+		if tu.Source != "" {
+			entry.Localizations[sourceLang] = Localization{StringUnit: &StringUnit{State: "translated", Value: tu.Source}}
+		}
+		if targetLang != "" && tu.Target != "" {
+			entry.Localizations[targetLang] = Localization{StringUnit: &StringUnit{State: "translated", Value: tu.Target}}
+		}
+		xc.Strings[tu.ID] = entry
+	}
+	return xc, nil
+}
+
+func (f xliffFormat) Write(w io.Writer, xc *Xcstrings) error {
+	lang, err := requireLang(f.lang, "xliff")
+	if err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(xc.Strings))
+	for key := range xc.Strings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	doc := xliffDocument{
+		Version: "1.2",
+		File: xliffFile{
+			Original:       "Localizable.xcstrings",
+			SourceLanguage: xc.SourceLanguage,
+			TargetLanguage: lang,
+			Datatype:       "plaintext",
+		},
+	}
+	for _, key := range keys {
+		entry := xc.Strings[key]
+		source := key
+		if loc, ok := entry.Localizations[xc.SourceLanguage]; ok && loc.StringUnit != nil {
+			source = loc.StringUnit.Value
+		}
+		var target string
+		if loc, ok := entry.Localizations[lang]; ok {
+			if loc.StringUnit != nil {
+				target = loc.StringUnit.Value
+			} else if loc.Variations != nil {
+				fmt.Fprintf(os.Stderr, "warning: %q has plural/device variations, which XLIFF 1.2 cannot represent; skipping\n", key)
+			}
+		}
+		doc.File.Body.TransUnits = append(doc.File.Body.TransUnits, xliffTransUnit{ID: key, Source: source, Target: target})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return fmt.Errorf("error encoding XLIFF: %v", err)
+	}
+	_, err = io.WriteString(w, "\n")
+	return err
+}
+
+// --- Android res/values-xx/strings.xml --------------------------------------
+
+type androidResources struct {
+	XMLName xml.Name        `xml:"resources"`
+	Strings []androidString `xml:"string"`
+}
+
+type androidString struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:",chardata"`
+}
+
+// androidFormat reads and writes a single Android res/values-xx/strings.xml
+// file. lang selects which xcstrings locale to read from or write into.
+type androidFormat struct {
+	lang string
+}
+
+func (f androidFormat) Read(r io.Reader) (*Xcstrings, error) {
+	lang, err := requireLang(f.lang, "android")
+	if err != nil {
+		return nil, err
+	}
+
+	var res androidResources
+	if err := xml.NewDecoder(r).Decode(&res); err != nil {
+		return nil, fmt.Errorf("error decoding Android strings.xml: %v", err)
+	}
+
+	xc := &Xcstrings{SourceLanguage: lang, Strings: make(map[string]StringEntry), Version: "1.0"}
+	for _, s := range res.Strings {
+		xc.Strings[s.Name] = StringEntry{
+			ExtractionState: "manual",
+			Localizations: map[string]Localization{
+				lang: {StringUnit: &StringUnit{State: "translated", Value: androidUnescape(s.Value)}},
+			},
+		}
+	}
+	return xc, nil
+}
+
+func (f androidFormat) Write(w io.Writer, xc *Xcstrings) error {
+	lang, err := requireLang(f.lang, "android")
+	if err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(xc.Strings))
+	for key := range xc.Strings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	res := androidResources{}
+	for _, key := range keys {
+		loc, ok := xc.Strings[key].Localizations[lang]
+		if !ok || loc.StringUnit == nil {
+			fmt.Fprintf(os.Stderr, "warning: %q has no %q translation; skipping\n", key, lang)
+			continue
+		}
+		res.Strings = append(res.Strings, androidString{Name: key, Value: androidEscape(loc.StringUnit.Value)})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "    ")
+	if err := encoder.Encode(res); err != nil {
+		return fmt.Errorf("error encoding Android strings.xml: %v", err)
+	}
+	_, err = io.WriteString(w, "\n")
+	return err
+}
+
+// androidEscape applies Android's string-resource escaping rules (on top of
+// the XML entity escaping encoding/xml already does for &, <, > and ").
+func androidEscape(s string) string {
+	s = strings.ReplaceAll(s, "\\", "\\\\")
+	s = strings.ReplaceAll(s, "'", "\\'")
+	s = strings.ReplaceAll(s, "\n", "\\n")
+	return s
+}
+
+// androidUnescape reverses androidEscape.
+func androidUnescape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i == len(s)-1 {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n':
+			b.WriteByte('\n')
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+// --- Gettext PO/POT ----------------------------------------------------------
+
+// poFormat reads and writes Gettext .po/.pot files, storing the xcstrings key
+// in msgctxt, the source-language text in msgid, and the translation in
+// msgstr. lang selects which locale a msgstr maps to.
+type poFormat struct {
+	lang string
+}
+
+type poEntry struct {
+	Context string
+	ID      string
+	Str     string
+}
+
+func (f poFormat) Read(r io.Reader) (*Xcstrings, error) {
+	lang, err := requireLang(f.lang, "po")
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := parsePoEntries(r)
+	if err != nil {
+		return nil, err
+	}
+
+	xc := &Xcstrings{SourceLanguage: "en", Strings: make(map[string]StringEntry), Version: "1.0"}
+	for _, e := range entries {
+		if e.ID == "" {
+			continue // the header entry (empty msgid) carries metadata we don't model
+		}
+		key := e.Context
+		if key == "" {
+			key = e.ID
+		}
+		entry := StringEntry{ExtractionState: "manual", Localizations: map[string]Localization{
+			xc.SourceLanguage: {StringUnit: &StringUnit{State: "translated", Value: e.ID}},
+		}}
+		if e.Str != "" {
+			entry.Localizations[lang] = Localization{StringUnit: &StringUnit{State: "translated", Value: e.Str}}
+		}
+		xc.Strings[key] = entry
+	}
+	return xc, nil
+}
+
+func (f poFormat) Write(w io.Writer, xc *Xcstrings) error {
+	lang, err := requireLang(f.lang, "po")
+	if err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(xc.Strings))
+	for key := range xc.Strings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	bw := bufio.NewWriter(w)
+	for _, key := range keys {
+		entry := xc.Strings[key]
+		source := key
+		if loc, ok := entry.Localizations[xc.SourceLanguage]; ok && loc.StringUnit != nil {
+			source = loc.StringUnit.Value
+		}
+		var target string
+		if loc, ok := entry.Localizations[lang]; ok && loc.StringUnit != nil {
+			target = loc.StringUnit.Value
+		}
+		fmt.Fprintf(bw, "msgctxt %s\n", strconv.Quote(key))
+		fmt.Fprintf(bw, "msgid %s\n", strconv.Quote(source))
+		fmt.Fprintf(bw, "msgstr %s\n\n", strconv.Quote(target))
+	}
+	return bw.Flush()
+}
+
+// parsePoEntries performs a minimal PO parse: msgctxt/msgid/msgstr keywords
+// followed by a double-quoted Go-style string, one entry per blank-separated
+// block. It does not handle comments, plural forms, or multi-line string
+// continuations beyond consecutive quoted lines for the same keyword.
+func parsePoEntries(r io.Reader) ([]poEntry, error) {
+	var entries []poEntry
+	var current poEntry
+	var field *string
+	started := false
+
+	flush := func() {
+		if started {
+			entries = append(entries, current)
+		}
+		current = poEntry{}
+		field = nil
+		started = false
+	}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "" || strings.HasPrefix(line, "#"):
+			if line == "" {
+				flush()
+			}
+			continue
+		case strings.HasPrefix(line, "msgctxt "):
+			flush()
+			started = true
+			value, err := strconv.Unquote(strings.TrimSpace(line[len("msgctxt "):]))
+			if err != nil {
+				return nil, fmt.Errorf("error parsing msgctxt: %v", err)
+			}
+			current.Context = value
+			field = &current.Context
+		case strings.HasPrefix(line, "msgid "):
+			started = true
+			value, err := strconv.Unquote(strings.TrimSpace(line[len("msgid "):]))
+			if err != nil {
+				return nil, fmt.Errorf("error parsing msgid: %v", err)
+			}
+			current.ID = value
+			field = &current.ID
+		case strings.HasPrefix(line, "msgstr "):
+			started = true
+			value, err := strconv.Unquote(strings.TrimSpace(line[len("msgstr "):]))
+			if err != nil {
+				return nil, fmt.Errorf("error parsing msgstr: %v", err)
+			}
+			current.Str = value
+			field = &current.Str
+		case strings.HasPrefix(line, `"`) && field != nil:
+			value, err := strconv.Unquote(line)
+			if err != nil {
+				return nil, fmt.Errorf("error parsing PO string continuation: %v", err)
+			}
+			*field += value
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading PO file: %v", err)
+	}
+	flush()
+	return entries, nil
+}
+
+// --- Flat key -> value maps (JSON/YAML/TOML) --------------------------------
+
+type flatKind int
+
+const (
+	flatJSON flatKind = iota
+	flatYAML
+	flatTOML
+)
+
+func (k flatKind) String() string {
+	switch k {
+	case flatJSON:
+		return "json"
+	case flatYAML:
+		return "yaml"
+	case flatTOML:
+		return "toml"
+	default:
+		return "unknown"
+	}
+}
+
+// flatMapFormat reads and writes a single locale as a flat "key -> value"
+// map, the shape go-i18n's merge command juggles for JSON/YAML/TOML message
+// files. Only scalar string values are supported - no nested maps, arrays,
+// or plural sub-keys. Both keys and values are quoted per the target
+// format's own string-literal grammar: YAML's double-quoted scalar grammar
+// (a superset of JSON's, so json.Marshal/Unmarshal on the bare string is
+// spec-compliant), or TOML's basic string grammar (see tomlEscape/
+// tomlUnescape). Quoting the key isn't optional - xcstrings keys are often
+// literal UI sentences (spaces, punctuation, a leading "#"), none of which
+// survive as a bare TOML key or past this format's own "#"-is-a-comment
+// convention unquoted. Either file is a valid, flat-only YAML/TOML document
+// a real parser can load - it just won't round trip documents with nested
+// structure, which this format doesn't model.
+type flatMapFormat struct {
+	lang string
+	kind flatKind
+}
+
+func (f flatMapFormat) Read(r io.Reader) (*Xcstrings, error) {
+	lang, err := requireLang(f.lang, f.kind.String())
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %v", f.kind, err)
+	}
+
+	var values map[string]string
+	switch f.kind {
+	case flatJSON:
+		if err := json.Unmarshal(data, &values); err != nil {
+			return nil, fmt.Errorf("error decoding flat JSON: %v", err)
+		}
+	case flatYAML:
+		values, err = parseFlatLines(data, ":", yamlUnquote)
+		if err != nil {
+			return nil, fmt.Errorf("error decoding flat YAML: %v", err)
+		}
+	case flatTOML:
+		values, err = parseFlatLines(data, "=", tomlUnquote)
+		if err != nil {
+			return nil, fmt.Errorf("error decoding flat TOML: %v", err)
+		}
+	}
+
+	xc := &Xcstrings{SourceLanguage: lang, Strings: make(map[string]StringEntry), Version: "1.0"}
+	for key, value := range values {
+		xc.Strings[key] = StringEntry{
+			ExtractionState: "manual",
+			Localizations: map[string]Localization{
+				lang: {StringUnit: &StringUnit{State: "translated", Value: value}},
+			},
+		}
+	}
+	return xc, nil
+}
+
+func (f flatMapFormat) Write(w io.Writer, xc *Xcstrings) error {
+	lang, err := requireLang(f.lang, f.kind.String())
+	if err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(xc.Strings))
+	for key := range xc.Strings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	switch f.kind {
+	case flatJSON:
+		values := make(map[string]string, len(keys))
+		for _, key := range keys {
+			if loc, ok := xc.Strings[key].Localizations[lang]; ok && loc.StringUnit != nil {
+				values[key] = loc.StringUnit.Value
+			}
+		}
+		data, err := json.MarshalIndent(values, "", "  ")
+		if err != nil {
+			return fmt.Errorf("error encoding flat JSON: %v", err)
+		}
+		_, err = w.Write(data)
+		return err
+	case flatYAML, flatTOML:
+		sep := ":"
+		quote := yamlQuote
+		if f.kind == flatTOML {
+			sep = "="
+			quote = tomlQuote
+		}
+		bw := bufio.NewWriter(w)
+		for _, key := range keys {
+			loc, ok := xc.Strings[key].Localizations[lang]
+			if !ok || loc.StringUnit == nil {
+				continue
+			}
+			fmt.Fprintf(bw, "%s %s %s\n", quote(key), sep, quote(loc.StringUnit.Value))
+		}
+		return bw.Flush()
+	default:
+		return fmt.Errorf("unsupported flat map kind: %v", f.kind)
+	}
+}
+
+// parseFlatLines parses "<quoted-key><sep> <quoted-value>" lines as produced
+// by flatMapFormat.Write, skipping blank lines and "//" comments. A line
+// starting with "#" is only treated as a comment if it isn't a quoted key -
+// since every key flatMapFormat.Write emits is quoted (line starts with
+// '"'), a literal key like "#1 best seller" is never mistaken for one.
+// unquote decodes both the key and value halves per the target format's own
+// string-literal grammar (yamlUnquote or tomlUnquote) rather than Go's.
+func parseFlatLines(data []byte, sep string, unquote func(string) (string, error)) (map[string]string, error) {
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(strings.NewReader(string(data)))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "//") {
+			continue
+		}
+		if strings.HasPrefix(line, "#") && !strings.HasPrefix(line, `"`) {
+			continue
+		}
+
+		keyLiteral, valueLiteral, err := splitQuotedKeyLine(line, sep)
+		if err != nil {
+			return nil, err
+		}
+		key, err := unquote(keyLiteral)
+		if err != nil {
+			return nil, fmt.Errorf("malformed key %q: %v", keyLiteral, err)
+		}
+		value, err := unquote(valueLiteral)
+		if err != nil {
+			return nil, fmt.Errorf("malformed value for key %q: %v", key, err)
+		}
+		values[key] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// splitQuotedKeyLine splits a `"<key>"<sep> <value>` line into its quoted
+// key and value halves, still quoted and ready for `unquote`. It only scans
+// for the key literal's closing, unescaped double quote - it doesn't
+// interpret escapes itself, so it works the same for both the YAML/JSON and
+// TOML quoting grammars.
+func splitQuotedKeyLine(line, sep string) (keyLiteral, valueLiteral string, err error) {
+	if len(line) == 0 || line[0] != '"' {
+		return "", "", fmt.Errorf("expected a quoted key, got: %s", line)
+	}
+	i := 1
+	for i < len(line) && line[i] != '"' {
+		if line[i] == '\\' && i+1 < len(line) {
+			i++
+		}
+		i++
+	}
+	if i >= len(line) {
+		return "", "", fmt.Errorf("unterminated quoted key: %s", line)
+	}
+	keyLiteral = line[:i+1]
+
+	rest := strings.TrimSpace(line[i+1:])
+	if !strings.HasPrefix(rest, sep) {
+		return "", "", fmt.Errorf("expected %q after key: %s", sep, line)
+	}
+	valueLiteral = strings.TrimSpace(rest[len(sep):])
+	return keyLiteral, valueLiteral, nil
+}
+
+// yamlQuote renders s as a YAML double-quoted scalar. YAML's double-quoted
+// escape grammar is a superset of JSON's (both support \", \\, \n, \t, and
+// \uXXXX), so a JSON string literal is also a valid YAML one; it never fails
+// for a Go string. HTML escaping is disabled - json.Marshal's default
+// </>/& escaping exists for embedding JSON in HTML <script>
+// tags, which doesn't apply here and would otherwise mangle the very
+// "<b>...</b> & ..." markup translators commonly put in UI strings.
+func yamlQuote(s string) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s) // never fails for a string; appends a trailing newline
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// yamlUnquote is the inverse of yamlQuote: s is expected to be a
+// double-quoted YAML (or JSON) scalar literal, including its surrounding quotes.
+func yamlUnquote(s string) (string, error) {
+	var value string
+	if err := json.Unmarshal([]byte(s), &value); err != nil {
+		return "", err
+	}
+	return value, nil
+}
+
+// tomlEscape escapes s for use inside a TOML basic (double-quoted) string,
+// per the TOML basic-string escape grammar: \b \t \n \f \r \" \\, and \uXXXX
+// for any other control character. Unlike strconv.Quote, it never emits
+// Go-only escapes (\xHH, \a, \v, ...) that TOML parsers reject.
+func tomlEscape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '\\':
+			b.WriteString(`\\`)
+		case '"':
+			b.WriteString(`\"`)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\f':
+			b.WriteString(`\f`)
+		case '\r':
+			b.WriteString(`\r`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(&b, `\u%04x`, r)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	return b.String()
+}
+
+// tomlQuote wraps s in double quotes, escaping its contents per tomlEscape.
+func tomlQuote(s string) string {
+	return `"` + tomlEscape(s) + `"`
+}
+
+// tomlUnescape decodes a TOML basic string's contents (without its
+// surrounding quotes), per the same escape grammar tomlEscape emits, plus
+// \UXXXXXXXX for code points outside the basic multilingual plane.
+func tomlUnescape(s string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' {
+			b.WriteByte(s[i])
+			continue
+		}
+		if i == len(s)-1 {
+			return "", fmt.Errorf("trailing backslash")
+		}
+		i++
+		switch s[i] {
+		case 'b':
+			b.WriteByte('\b')
+		case 't':
+			b.WriteByte('\t')
+		case 'n':
+			b.WriteByte('\n')
+		case 'f':
+			b.WriteByte('\f')
+		case 'r':
+			b.WriteByte('\r')
+		case '"':
+			b.WriteByte('"')
+		case '\\':
+			b.WriteByte('\\')
+		case 'u', 'U':
+			width := 4
+			if s[i] == 'U' {
+				width = 8
+			}
+			if i+width >= len(s) {
+				return "", fmt.Errorf("truncated \\%c escape", s[i])
+			}
+			code, err := strconv.ParseInt(s[i+1:i+1+width], 16, 32)
+			if err != nil {
+				return "", fmt.Errorf("invalid \\%c escape: %v", s[i], err)
+			}
+			b.WriteRune(rune(code))
+			i += width
+		default:
+			return "", fmt.Errorf("invalid escape \\%c", s[i])
+		}
+	}
+	return b.String(), nil
+}
+
+// tomlUnquote is the inverse of tomlQuote: s must be a double-quoted TOML
+// basic string literal, including its surrounding quotes.
+func tomlUnquote(s string) (string, error) {
+	if len(s) < 2 || s[0] != '"' || s[len(s)-1] != '"' {
+		return "", fmt.Errorf("value is not a double-quoted TOML string: %s", s)
+	}
+	return tomlUnescape(s[1 : len(s)-1])
+}
+
+// convertFile converts inputFile (in fromName's format) to outputFile (in
+// toName's format) via the format registry. lang is forwarded to any
+// single-locale format involved.
+func convertFile(inputFile, outputFile, fromName, toName, lang string, csvOpts csvOptions) error {
+	fromFormat, err := lookupFormat(fromName, lang, csvOpts)
+	if err != nil {
+		return err
+	}
+	toFormat, err := lookupFormat(toName, lang, csvOpts)
+	if err != nil {
+		return err
+	}
+
+	in, err := os.Open(inputFile)
+	if err != nil {
+		return fmt.Errorf("error opening input file: %v", err)
+	}
+	defer in.Close()
+
+	xc, err := fromFormat.Read(in)
+	if err != nil {
+		return fmt.Errorf("error reading %s: %v", fromName, err)
+	}
+
+	out, err := os.Create(outputFile)
+	if err != nil {
+		return fmt.Errorf("error creating output file: %v", err)
+	}
+	defer out.Close()
+
+	if err := toFormat.Write(out, xc); err != nil {
+		return fmt.Errorf("error writing %s: %v", toName, err)
+	}
+
+	fmt.Printf("Converted %s (%s) -> %s (%s)\n", inputFile, fromName, outputFile, toName)
+	return nil
+}
+
+// parseDelimiter validates the -delimiter flag, which must name exactly one
+// character, and returns it as a rune for csv.Reader/csv.Writer.Comma.
+func parseDelimiter(s string) (rune, bool) {
+	runes := []rune(s)
+	if len(runes) != 1 {
+		return 0, false
+	}
+	return runes[0], true
+}
+
+// main is the entry point of the program, parsing command-line flags and executing the chosen mode.
+func main() {
+	// Define command-line flags
+	mode := flag.String("mode", "", "Operation mode: 'json2csv', 'csv2json', 'merge', 'extract', 'compile', or 'convert' (default; see -from/-to)")
+	input := flag.String("input", "", "Input file path")
+	output := flag.String("output", "", "Output file path")
+	base := flag.String("base", "", "Base .xcstrings file for -mode merge, whose metadata is preserved")
+	src := flag.String("src", "", "Source directory to scan for localization call sites in -mode extract")
+	outdir := flag.String("outdir", "", "Output directory for -mode compile's per-language .lproj folders")
+	from := flag.String("from", "", "Source format for -mode convert (xcstrings, csv, xliff, android, po, json, yaml, toml); inferred from -input's extension when omitted")
+	to := flag.String("to", "", "Destination format for -mode convert; inferred from -output's extension when omitted")
+	lang := flag.String("lang", "", "Locale code to read/write for single-locale formats (xliff, android, po, json, yaml, toml)")
+	delimiter := flag.String("delimiter", ",", "CSV field delimiter (a single character)")
+	encoding := flag.String("encoding", "", "CSV text encoding: '' (utf-8, default) or 'windows-1252'/'cp1252'. Other golang.org/x/text encodings (e.g. gbk, shift-jis) are NOT implemented and are rejected with an error")
+	writeBOM := flag.Bool("bom", false, "Write a UTF-8 BOM on CSV output (for Excel on Windows)")
+	flag.Parse()
+
+	usage := func() {
+		fmt.Println("Usage:")
+		fmt.Println("  go run localization_converter.go -mode json2csv -input Localizable.xcstrings -output translations.csv")
+		fmt.Println("  go run localization_converter.go -mode csv2json -input translations.csv -output Localizable.xcstrings")
+		fmt.Println("  go run localization_converter.go -mode merge -base Localizable.xcstrings -input translations.csv -output Localizable.xcstrings")
+		fmt.Println("  go run localization_converter.go -mode extract -src ./Sources -output Localizable.xcstrings")
+		fmt.Println("  go run localization_converter.go -mode compile -input Localizable.xcstrings -outdir Generated")
+		fmt.Println("  go run localization_converter.go -input Localizable.xcstrings -output strings.xml -to android -lang fr")
+	}
+
+	resolvedModeForValidation := *mode
+	if resolvedModeForValidation == "" {
+		resolvedModeForValidation = "convert"
+	}
+
+	// Validate input/output flags; -mode extract reads from -src instead of
+	// -input, and -mode compile writes to -outdir instead of -output.
+	if resolvedModeForValidation == "compile" {
+		if *input == "" || *outdir == "" {
+			fmt.Println("Error: -mode compile requires -input and -outdir")
+			usage()
+			os.Exit(1)
+		}
+	} else if *output == "" || (*input == "" && resolvedModeForValidation != "extract") {
+		fmt.Println("Error: -input and -output flags are required (-mode extract takes -src instead of -input)")
+		usage()
+		os.Exit(1)
+	}
+	if resolvedModeForValidation == "extract" && *src == "" {
+		fmt.Println("Error: -mode extract requires -src")
+		usage()
+		os.Exit(1)
+	}
+
+	delimiterRune, ok := parseDelimiter(*delimiter)
+	if !ok {
+		fmt.Printf("Error: -delimiter must be exactly one character (got %q)\n", *delimiter)
+		os.Exit(1)
+	}
+	csvOpts := csvOptions{Delimiter: delimiterRune, Encoding: *encoding, WriteBOM: *writeBOM}
+
+	resolvedMode := resolvedModeForValidation
+
+	var err error
+	switch resolvedMode {
+	case "json2csv":
+		err = jsonToCsv(*input, *output, csvOpts)
+	case "csv2json":
+		err = csvToJson(*input, *output, csvOpts)
+	case "merge":
+		if *base == "" {
+			fmt.Println("Error: -mode merge requires -base")
+			usage()
+			os.Exit(1)
+		}
+		err = mergeCsvIntoXcstrings(*base, *input, *output, csvOpts)
+	case "extract":
+		err = extractSwiftIntoXcstrings(*src, *output, *lang)
+	case "compile":
+		err = compileXcstrings(*input, *outdir)
+	case "convert":
+		fromName := *from
+		if fromName == "" {
+			detected, ok := formatFromExt(*input)
+			if !ok {
+				fmt.Fprintf(os.Stderr, "Error: -from is required; could not infer a format from %q\n", *input)
+				os.Exit(1)
+			}
+			fromName = detected
+		}
+		toName := *to
+		if toName == "" {
+			detected, ok := formatFromExt(*output)
+			if !ok {
+				fmt.Fprintf(os.Stderr, "Error: -to is required; could not infer a format from %q\n", *output)
+				os.Exit(1)
+			}
+			toName = detected
+		}
+		err = convertFile(*input, *output, fromName, toName, *lang, csvOpts)
+	default:
+		fmt.Printf("Error: -mode must be 'json2csv', 'csv2json', 'merge', 'extract', 'compile', or 'convert' (got %q)\n", resolvedMode)
+		usage()
+		os.Exit(1)
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
 	}
 }
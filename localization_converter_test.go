@@ -0,0 +1,345 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+// TestXliffRoundTrip verifies that a catalog's source and target text for one
+// locale survives xliffFormat.Write -> xliffFormat.Read.
+func TestXliffRoundTrip(t *testing.T) {
+	xc := &Xcstrings{
+		SourceLanguage: "en",
+		Version:        "1.0",
+		Strings: map[string]StringEntry{
+			"greeting": {
+				ExtractionState: "manual",
+				Localizations: map[string]Localization{
+					"en": {StringUnit: &StringUnit{State: "translated", Value: "Hello"}},
+					"fr": {StringUnit: &StringUnit{State: "translated", Value: "Bonjour"}},
+				},
+			},
+		},
+	}
+
+	f := xliffFormat{lang: "fr"}
+	var buf bytes.Buffer
+	if err := f.Write(&buf, xc); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	got, err := f.Read(&buf)
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+
+	entry := got.Strings["greeting"]
+	if v := entry.Localizations["en"].StringUnit.Value; v != "Hello" {
+		t.Errorf("en = %q, want %q", v, "Hello")
+	}
+	if v := entry.Localizations["fr"].StringUnit.Value; v != "Bonjour" {
+		t.Errorf("fr = %q, want %q", v, "Bonjour")
+	}
+}
+
+// TestAndroidRoundTrip verifies that a single locale's translations survive
+// androidFormat.Write -> androidFormat.Read, including characters (', &,
+// newline) androidEscape/androidUnescape must round-trip.
+func TestAndroidRoundTrip(t *testing.T) {
+	xc := &Xcstrings{
+		SourceLanguage: "fr",
+		Version:        "1.0",
+		Strings: map[string]StringEntry{
+			"greeting": {Localizations: map[string]Localization{
+				"fr": {StringUnit: &StringUnit{State: "translated", Value: "Bonjour l'ami & au revoir\n"}},
+			}},
+		},
+	}
+
+	f := androidFormat{lang: "fr"}
+	var buf bytes.Buffer
+	if err := f.Write(&buf, xc); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	got, err := f.Read(&buf)
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+
+	want := "Bonjour l'ami & au revoir\n"
+	if v := got.Strings["greeting"].Localizations["fr"].StringUnit.Value; v != want {
+		t.Errorf("fr = %q, want %q", v, want)
+	}
+}
+
+// TestPoRoundTrip verifies that a catalog's source and target text survives
+// poFormat.Write -> poFormat.Read, with the xcstrings key preserved via msgctxt.
+func TestPoRoundTrip(t *testing.T) {
+	xc := &Xcstrings{
+		SourceLanguage: "en",
+		Version:        "1.0",
+		Strings: map[string]StringEntry{
+			"greeting": {
+				ExtractionState: "manual",
+				Localizations: map[string]Localization{
+					"en": {StringUnit: &StringUnit{State: "translated", Value: "Hello"}},
+					"fr": {StringUnit: &StringUnit{State: "translated", Value: "Bonjour"}},
+				},
+			},
+		},
+	}
+
+	f := poFormat{lang: "fr"}
+	var buf bytes.Buffer
+	if err := f.Write(&buf, xc); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	got, err := f.Read(&buf)
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+
+	entry := got.Strings["greeting"]
+	if v := entry.Localizations["en"].StringUnit.Value; v != "Hello" {
+		t.Errorf("en = %q, want %q", v, "Hello")
+	}
+	if v := entry.Localizations["fr"].StringUnit.Value; v != "Bonjour" {
+		t.Errorf("fr = %q, want %q", v, "Bonjour")
+	}
+}
+
+// TestFlatJSONRoundTrip verifies flatMapFormat.Write -> Read for flatJSON.
+func TestFlatJSONRoundTrip(t *testing.T) {
+	testFlatMapRoundTrip(t, flatJSON, map[string]string{
+		"greeting": "Hello & welcome",
+	})
+}
+
+// TestFlatYAMLRoundTrip verifies flatMapFormat.Write -> Read for flatYAML,
+// including keys that are illegal as bare YAML/TOML identifiers and a key
+// that collides with the format's own "#"-is-a-comment convention - the
+// exact cases that silently broke or dropped entries before keys were quoted.
+func TestFlatYAMLRoundTrip(t *testing.T) {
+	testFlatMapRoundTrip(t, flatYAML, map[string]string{
+		"Are you sure you want to delete this item?": "Confirm deletion",
+		"Error: Something went wrong":                "Generic error",
+		"#1 best seller":                             "Top seller badge",
+	})
+}
+
+// TestFlatTOMLRoundTrip is TestFlatYAMLRoundTrip's TOML counterpart: the
+// same tricky keys, but bare TOML keys are even stricter (no spaces or
+// punctuation at all), so this is where quoting matters most.
+func TestFlatTOMLRoundTrip(t *testing.T) {
+	testFlatMapRoundTrip(t, flatTOML, map[string]string{
+		"Are you sure you want to delete this item?": "Confirm deletion",
+		"Error: Something went wrong":                "Generic error",
+		"#1 best seller":                             "Top seller badge",
+	})
+}
+
+// testFlatMapRoundTrip is the shared core of the flat-map format tests: it
+// builds a catalog from values, writes it with kind's Format, reads it back,
+// and checks every key/value survived unchanged.
+func testFlatMapRoundTrip(t *testing.T, kind flatKind, values map[string]string) {
+	t.Helper()
+
+	xc := &Xcstrings{SourceLanguage: "en", Version: "1.0", Strings: make(map[string]StringEntry)}
+	for key, value := range values {
+		xc.Strings[key] = StringEntry{Localizations: map[string]Localization{
+			"en": {StringUnit: &StringUnit{State: "translated", Value: value}},
+		}}
+	}
+
+	f := flatMapFormat{lang: "en", kind: kind}
+	var buf bytes.Buffer
+	if err := f.Write(&buf, xc); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	got, err := f.Read(&buf)
+	if err != nil {
+		t.Fatalf("Read (output was):\n%s\nerror: %v", buf.String(), err)
+	}
+
+	if len(got.Strings) != len(values) {
+		t.Fatalf("got %d keys, want %d (output was):\n%s", len(got.Strings), len(values), buf.String())
+	}
+	for key, want := range values {
+		entry, ok := got.Strings[key]
+		if !ok {
+			t.Errorf("missing key %q after round trip (output was):\n%s", key, buf.String())
+			continue
+		}
+		if v := entry.Localizations["en"].StringUnit.Value; v != want {
+			t.Errorf("key %q = %q, want %q", key, v, want)
+		}
+	}
+}
+
+// TestCsvPluralRoundTrip verifies that a catalog with plural variations
+// survives writeCsvToWriter -> readCsvFromReader unchanged: each CLDR
+// category becomes its own "key|plural=category" row and reassembles into
+// the same Variations map.
+func TestCsvPluralRoundTrip(t *testing.T) {
+	xc := &Xcstrings{
+		SourceLanguage: "en",
+		Version:        "1.0",
+		Strings: map[string]StringEntry{
+			"item_count": {
+				ExtractionState: "manual",
+				Localizations: map[string]Localization{
+					"en": {Variations: &Variations{Plural: map[string]VariationUnit{
+						"one":   {StringUnit: StringUnit{State: "translated", Value: "1 item"}},
+						"other": {StringUnit: StringUnit{State: "translated", Value: "%d items"}},
+					}}},
+					"fr": {Variations: &Variations{Plural: map[string]VariationUnit{
+						"one":   {StringUnit: StringUnit{State: "translated", Value: "1 élément"}},
+						"other": {StringUnit: StringUnit{State: "translated", Value: "%d éléments"}},
+					}}},
+				},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := writeCsvToWriter(&buf, xc, []string{"en", "fr"}, csvOptions{}); err != nil {
+		t.Fatalf("writeCsvToWriter: %v", err)
+	}
+
+	got, _, err := readCsvFromReader(&buf, csvOptions{})
+	if err != nil {
+		t.Fatalf("readCsvFromReader: %v", err)
+	}
+
+	entry, ok := got.Strings["item_count"]
+	if !ok {
+		t.Fatalf("round-tripped catalog is missing %q", "item_count")
+	}
+	for _, lang := range []string{"en", "fr"} {
+		for _, category := range []string{"one", "other"} {
+			want := xc.Strings["item_count"].Localizations[lang].Variations.Plural[category].StringUnit.Value
+			got := entry.Localizations[lang].Variations.Plural[category].StringUnit.Value
+			if got != want {
+				t.Errorf("lang %q plural=%q: got %q, want %q", lang, category, got, want)
+			}
+		}
+	}
+}
+
+// TestMergeTranslationsPreservesAndReportsInvalid exercises mergeTranslations'
+// per-row summary: a blank cell leaves an existing translation untouched (and
+// its key counts as Unchanged), a brand-new key counts as Added, an
+// untouched pre-existing key counts as Stale, and a row with an unparseable
+// variation suffix is counted as Invalid rather than dropped (or miscounted
+// as Stale).
+func TestMergeTranslationsPreservesAndReportsInvalid(t *testing.T) {
+	base := &Xcstrings{
+		SourceLanguage: "en",
+		Version:        "1.0",
+		Strings: map[string]StringEntry{
+			"greeting": {
+				ExtractionState: "manual",
+				Localizations: map[string]Localization{
+					"en": {StringUnit: &StringUnit{State: "translated", Value: "Hello"}},
+					"fr": {StringUnit: &StringUnit{State: "translated", Value: "Bonjour"}},
+				},
+			},
+			"farewell": {
+				ExtractionState: "manual",
+				Localizations: map[string]Localization{
+					"en": {StringUnit: &StringUnit{State: "translated", Value: "Goodbye"}},
+					"fr": {StringUnit: &StringUnit{State: "translated", Value: "Au revoir"}},
+				},
+			},
+		},
+	}
+
+	languages := []string{"en", "fr"}
+	rows := [][]string{
+		{"greeting", "Hello", ""},                         // blank cell: fr translation must survive untouched
+		{"new_key", "New", "Nouveau"},                     // brand-new key
+		{"greeting|plural=bogus", "Hello", "Salut"},       // unknown plural category on an existing key: must not silently drop or count as Stale
+		{"never_seen_key|plural=bogus", "Hello", "Salut"}, // unknown plural category on a key that doesn't exist at all: must not be created or counted as Added/Unchanged
+	}
+
+	summary := mergeTranslations(base, languages, rows)
+
+	if summary.Added != 1 {
+		t.Errorf("Added = %d, want 1", summary.Added)
+	}
+	if summary.Invalid != 2 {
+		t.Errorf("Invalid = %d, want 2", summary.Invalid)
+	}
+	if summary.Unchanged != 1 { // "greeting" is touched by a valid row with a blank fr cell
+		t.Errorf("Unchanged = %d, want 1", summary.Unchanged)
+	}
+	if summary.Stale != 1 { // only "farewell" was never mentioned in the CSV
+		t.Errorf("Stale = %d, want 1 (got %d)", summary.Stale, summary.Stale)
+	}
+
+	if got := base.Strings["greeting"].Localizations["fr"].StringUnit.Value; got != "Bonjour" {
+		t.Errorf("blank cell clobbered existing translation: got %q, want %q", got, "Bonjour")
+	}
+	if got := base.Strings["new_key"].Localizations["fr"].StringUnit.Value; got != "Nouveau" {
+		t.Errorf("new key translation = %q, want %q", got, "Nouveau")
+	}
+	if _, exists := base.Strings["never_seen_key"]; exists {
+		t.Errorf("an invalid-suffix row for a nonexistent key must not create an entry")
+	}
+}
+
+// TestYamlQuoteDoesNotHTMLEscape ensures flat-YAML values keep markup and
+// ampersands readable rather than being mangled into \uXXXX escapes, which
+// json.Marshal would do by default (it's meant for embedding in <script>
+// tags, not for a translator-editable file).
+func TestYamlQuoteDoesNotHTMLEscape(t *testing.T) {
+	got := yamlQuote("<b>Save</b> & continue")
+	want := `"<b>Save</b> & continue"`
+	if got != want {
+		t.Errorf("yamlQuote = %s, want %s", got, want)
+	}
+	back, err := yamlUnquote(got)
+	if err != nil {
+		t.Fatalf("yamlUnquote: %v", err)
+	}
+	if back != "<b>Save</b> & continue" {
+		t.Errorf("round-trip = %q", back)
+	}
+}
+
+// TestCompileStringsdictEscapesXML is a golden-output test for
+// compileStringsdict: values containing XML metacharacters must come out
+// entity-escaped, never raw, since the result is parsed as XML.
+func TestCompileStringsdictEscapesXML(t *testing.T) {
+	xc := &Xcstrings{
+		SourceLanguage: "en",
+		Version:        "1.0",
+		Strings: map[string]StringEntry{
+			"item_count": {
+				Localizations: map[string]Localization{
+					"en": {Variations: &Variations{Plural: map[string]VariationUnit{
+						"one":   {StringUnit: StringUnit{State: "translated", Value: "1 item & <more>"}},
+						"other": {StringUnit: StringUnit{State: "translated", Value: "%d items & <more>"}},
+					}}},
+				},
+			},
+		},
+	}
+
+	const want = "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n" +
+		"<!DOCTYPE plist PUBLIC \"-//Apple//DTD PLIST 1.0//EN\" \"http://www.apple.com/DTDs/PropertyList-1.0.dtd\">\n" +
+		"<plist version=\"1.0\">\n<dict>\n" +
+		"\t<key>item_count</key>\n\t<dict>\n" +
+		"\t\t<key>NSStringLocalizedFormatKey</key>\n\t\t<string>%#@value@</string>\n" +
+		"\t\t<key>value</key>\n\t\t<dict>\n" +
+		"\t\t\t<key>NSStringFormatSpecTypeKey</key>\n\t\t\t<string>NSStringPluralRuleType</string>\n" +
+		"\t\t\t<key>NSStringFormatValueTypeKey</key>\n\t\t\t<string>d</string>\n" +
+		"\t\t\t<key>one</key>\n\t\t\t<string>1 item &amp; &lt;more&gt;</string>\n" +
+		"\t\t\t<key>other</key>\n\t\t\t<string>%d items &amp; &lt;more&gt;</string>\n" +
+		"\t\t</dict>\n\t</dict>\n" +
+		"</dict>\n</plist>\n"
+
+	got := compileStringsdict(xc, []string{"item_count"}, "en")
+	if got != want {
+		t.Errorf("compileStringsdict output mismatch:\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}